@@ -1,44 +1,204 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
-const (
-	threads = 150
-	count   = 1_000_000
+const defaultRequests = 1_000_000
+
+var (
+	workerCount = flag.Int("workers", 150, "number of worker goroutines pulling from the URL queue")
+	requests    = flag.Int("requests", defaultRequests, "total number of requests to issue; ignored if --duration is set")
+	duration    = flag.Duration("duration", 0, `run for this long instead of a fixed request count, e.g. "10s"; 0 disables and uses --requests`)
+	rateFlag    = flag.String("rate", "", `global request rate limit across all workers, e.g. "5000" (per second) or "10/ms"; empty means unlimited`)
 )
 
 func main() {
-	var urls []string
-	for i := 0; i < count; i++ {
-		u := rand.Int31() % 1024
-		urls = append(urls, fmt.Sprintf("http://localhost:8080/rate/foo/%d", u))
+	flag.Parse()
+
+	limiter, err := parseRateLimiter(*rateFlag)
+	if err != nil {
+		fmt.Printf("invalid --rate: %v\n", err)
+		return
+	}
+
+	templates, err := loadTemplates()
+	if err != nil {
+		fmt.Printf("invalid scenario: %v\n", err)
+		return
+	}
+	scn, err := newScenario(templates)
+	if err != nil {
+		fmt.Printf("invalid scenario: %v\n", err)
+		return
+	}
+
+	ctx := context.Background()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
 	}
 
+	client := newClient()
+	telem, err := setupTelemetry(ctx, client)
+	if err != nil {
+		fmt.Printf("setting up telemetry: %v\n", err)
+		return
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		telem.shutdown(shutdownCtx)
+	}()
+
+	progress := newProgressReporter()
+	reporterCtx, stopReporter := context.WithCancel(context.Background())
+	go progress.run(reporterCtx)
+	defer stopReporter()
+
+	// A single producer streams rendered requests into a buffered channel
+	// so a slow worker never stalls the others, and the queue never holds
+	// more than a few thousand requests in memory regardless of --requests.
+	reqs := make(chan *http.Request, 1024)
+	go produceRequests(ctx, reqs, scn, *requests, *duration > 0)
+
 	wg := sync.WaitGroup{}
+	workers := make([]*workerStats, *workerCount)
 
 	start := time.Now()
-	for i := 0; i < threads; i++ {
+	for i := 0; i < *workerCount; i++ {
 		wg.Add(1)
-		local := urls[i*(count/threads) : (i+1)*(count/threads)]
-		client := http.Client{}
+		stats := newWorkerStats()
+		workers[i] = stats
 
 		go func() {
-			for j := range local {
-				client.Get(local[j])
+			defer wg.Done()
+			for req := range reqs {
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						// ctx is done (e.g. --duration elapsed); Wait
+						// returns immediately rather than actually
+						// pacing, so stop instead of bursting out the
+						// rest of the buffered channel unpaced.
+						return
+					}
+				}
+
+				progress.requestStarted()
+				telem.inFlight.Add(ctx, 1)
+				reqStart := time.Now()
+				resp, err := client.Do(req.WithContext(ctx))
+
+				status := 0
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					status = resp.StatusCode
+				}
+				elapsed := time.Since(reqStart)
+
+				stats.record(elapsed, status)
+				progress.requestFinished(elapsed, status)
+				telem.inFlight.Add(ctx, -1)
+				telem.requests.Add(ctx, 1)
+				telem.latency.Record(ctx, float64(elapsed.Milliseconds()))
 			}
-			wg.Done()
 		}()
 	}
 
 	wg.Wait()
 	done := time.Now()
 	total := done.Sub(start)
+	sent := totalRequests(workers)
+
+	fmt.Printf("took %d milliseconds to do %d requests in %d goroutines:\n \t - %f per second\n", total.Milliseconds(), sent, *workerCount, float64(sent)/total.Seconds())
+	mergeStats(workers)
+}
+
+// produceRequests renders requests from scn onto reqs until either n have
+// been produced or, when unbounded is true, ctx is done (the --duration
+// mode). It always closes reqs so worker goroutines exit their range loop.
+// A template error is treated as a producer failure and ends the run early.
+func produceRequests(ctx context.Context, reqs chan<- *http.Request, scn *scenario, n int, unbounded bool) {
+	defer close(reqs)
+	for i := 0; unbounded || i < n; i++ {
+		req, err := scn.next()
+		if err != nil {
+			fmt.Printf("rendering request: %v\n", err)
+			return
+		}
+
+		select {
+		case reqs <- req:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// totalRequests sums the successes and failures recorded by every worker,
+// since the final count is no longer known up front when running with
+// --duration.
+func totalRequests(workers []*workerStats) int64 {
+	var total int64
+	for _, w := range workers {
+		total += w.failed
+		for _, n := range w.statuses {
+			total += n
+		}
+	}
+	return total
+}
 
-	fmt.Printf("took %d milliseconds to do %d requests in %d goroutines:\n \t - %f per second\n", total.Milliseconds(), threads, count, count/total.Seconds())
+// parseRateLimiter turns a --rate value into a shared token-bucket limiter.
+// Accepts a plain number of requests per second ("5000") or a "<n>/<unit>"
+// form where unit is one of ms, s, m (e.g. "10/ms", "50/s"). An empty string
+// disables limiting and returns a nil limiter.
+func parseRateLimiter(raw string) (*rate.Limiter, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	n, per, ok := strings.Cut(raw, "/")
+	amount, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing rate %q: %w", raw, err)
+	}
+	if amount <= 0 {
+		// rate.NewLimiter with a non-positive limit never replenishes
+		// tokens, so Wait would block forever on every worker goroutine.
+		return nil, fmt.Errorf("rate %q must be greater than zero", raw)
+	}
+
+	window := time.Second
+	if ok {
+		switch per {
+		case "ms":
+			window = time.Millisecond
+		case "s":
+			window = time.Second
+		case "m":
+			window = time.Minute
+		default:
+			return nil, fmt.Errorf("unknown rate unit %q", per)
+		}
+	}
+
+	perSecond := amount / window.Seconds()
+	burst := int(perSecond)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), burst), nil
 }
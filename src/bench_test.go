@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "empty disables limiting", raw: "", wantNil: true},
+		{name: "plain number is per second", raw: "5000"},
+		{name: "explicit per-millisecond", raw: "10/ms"},
+		{name: "explicit per-minute", raw: "600/m"},
+		{name: "malformed number", raw: "abc", wantErr: true},
+		{name: "unknown unit", raw: "10/fortnight", wantErr: true},
+		{name: "zero rate is rejected, it would never release a token", raw: "0", wantErr: true},
+		{name: "negative rate is rejected, it would never release a token", raw: "-5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := parseRateLimiter(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRateLimiter(%q): expected error, got nil", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRateLimiter(%q): unexpected error: %v", tt.raw, err)
+			}
+			if tt.wantNil && limiter != nil {
+				t.Fatalf("parseRateLimiter(%q): expected nil limiter, got %v", tt.raw, limiter)
+			}
+			if !tt.wantNil && limiter == nil {
+				t.Fatalf("parseRateLimiter(%q): expected a limiter, got nil", tt.raw)
+			}
+		})
+	}
+}
+
+func TestTotalRequests(t *testing.T) {
+	a := newWorkerStats()
+	a.statuses[200] = 3
+	a.statuses[500] = 1
+	a.failed = 2
+
+	b := newWorkerStats()
+	b.statuses[200] = 5
+
+	got := totalRequests([]*workerStats{a, b})
+	want := int64(3 + 1 + 2 + 5)
+	if got != want {
+		t.Fatalf("totalRequests() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	otlpEndpoint = flag.String("otlp-endpoint", "", "OTLP gRPC endpoint (host:port) to export traces and metrics to; empty disables OpenTelemetry entirely")
+	interval     = flag.Duration("interval", time.Second, "how often to print a live progress snapshot to stderr")
+)
+
+// telemetry bundles the pieces needed to instrument the shared client and
+// to shut the exporters down cleanly once the run finishes.
+type telemetry struct {
+	shutdown func(context.Context) error
+	requests metric.Int64Counter
+	inFlight metric.Int64UpDownCounter
+	latency  metric.Float64Histogram
+}
+
+// setupTelemetry wires up OTLP trace and metric exporters when
+// --otlp-endpoint is set, wraps client's transport with otelhttp so every
+// outbound request gets its own span tagged with URL, status, and
+// duration, and returns the instruments used to additionally record
+// request count, in-flight count, and latency as metrics. When
+// --otlp-endpoint is empty it returns a no-op telemetry whose shutdown is
+// a no-op and whose instruments are backed by the global no-op providers.
+func setupTelemetry(ctx context.Context, client *http.Client) (*telemetry, error) {
+	// otelhttp only injects a traceparent header if a real propagator is
+	// installed; otel.GetTextMapPropagator() defaults to a no-op, which
+	// would silently produce spans that never correlate with the server
+	// under test.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	meter := otel.GetMeterProvider().Meter("pyre")
+
+	t := &telemetry{shutdown: func(context.Context) error { return nil }}
+	var err error
+	if t.requests, err = meter.Int64Counter("pyre.requests", metric.WithDescription("requests issued")); err != nil {
+		return nil, fmt.Errorf("creating requests counter: %w", err)
+	}
+	if t.inFlight, err = meter.Int64UpDownCounter("pyre.in_flight", metric.WithDescription("requests currently outstanding")); err != nil {
+		return nil, fmt.Errorf("creating in_flight counter: %w", err)
+	}
+	if t.latency, err = meter.Float64Histogram("pyre.latency_ms", metric.WithDescription("request latency in milliseconds")); err != nil {
+		return nil, fmt.Errorf("creating latency histogram: %w", err)
+	}
+
+	if *otlpEndpoint == "" {
+		client.Transport = otelhttp.NewTransport(client.Transport)
+		return t, nil
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(*otlpEndpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("creating trace exporter: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter))
+	otel.SetTracerProvider(tp)
+
+	metricExporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpoint(*otlpEndpoint), otlpmetricgrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("creating metric exporter: %w", err)
+	}
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	otel.SetMeterProvider(mp)
+
+	// Re-fetch the instruments now that the real meter provider is
+	// installed; the ones created above were backed by the no-op default.
+	meter = mp.Meter("pyre")
+	if t.requests, err = meter.Int64Counter("pyre.requests", metric.WithDescription("requests issued")); err != nil {
+		return nil, fmt.Errorf("creating requests counter: %w", err)
+	}
+	if t.inFlight, err = meter.Int64UpDownCounter("pyre.in_flight", metric.WithDescription("requests currently outstanding")); err != nil {
+		return nil, fmt.Errorf("creating in_flight counter: %w", err)
+	}
+	if t.latency, err = meter.Float64Histogram("pyre.latency_ms", metric.WithDescription("request latency in milliseconds")); err != nil {
+		return nil, fmt.Errorf("creating latency histogram: %w", err)
+	}
+
+	client.Transport = otelhttp.NewTransport(client.Transport)
+
+	t.shutdown = func(ctx context.Context) error {
+		if err := tp.Shutdown(ctx); err != nil {
+			return err
+		}
+		return mp.Shutdown(ctx)
+	}
+	return t, nil
+}
+
+// progressReporter prints a live snapshot every --interval: instantaneous
+// RPS, in-flight requests, error rate, and a rolling p99 computed only
+// from samples recorded since the last snapshot, so it reflects current
+// behavior rather than the run's lifetime average.
+type progressReporter struct {
+	inFlight int64
+	sent     int64
+	failed   int64
+
+	// rolling is written by every worker goroutine and read/reset by run,
+	// unlike a plain workerStats which assumes a single owner, so access
+	// to it is guarded by rollingMu.
+	rollingMu sync.Mutex
+	rolling   *workerStats
+}
+
+func newProgressReporter() *progressReporter {
+	return &progressReporter{rolling: newWorkerStats()}
+}
+
+func (p *progressReporter) requestStarted() {
+	atomic.AddInt64(&p.inFlight, 1)
+}
+
+func (p *progressReporter) requestFinished(elapsed time.Duration, status int) {
+	atomic.AddInt64(&p.inFlight, -1)
+	atomic.AddInt64(&p.sent, 1)
+	if status == 0 {
+		atomic.AddInt64(&p.failed, 1)
+	}
+
+	p.rollingMu.Lock()
+	p.rolling.record(elapsed, status)
+	p.rollingMu.Unlock()
+}
+
+// run prints one snapshot line per tick until ctx is done.
+func (p *progressReporter) run(ctx context.Context) {
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	var lastSent, lastFailed int64
+	for {
+		select {
+		case <-ticker.C:
+			sent := atomic.LoadInt64(&p.sent)
+			failed := atomic.LoadInt64(&p.failed)
+			windowSent := sent - lastSent
+			windowFailed := failed - lastFailed
+			lastSent, lastFailed = sent, failed
+
+			var errRate float64
+			if windowSent > 0 {
+				errRate = float64(windowFailed) / float64(windowSent) * 100
+			}
+
+			p.rollingMu.Lock()
+			p99 := p.rolling.hist.ValueAtQuantile(99)
+			p.rolling.hist.Reset()
+			p.rollingMu.Unlock()
+
+			fmt.Fprintf(os.Stderr, "rps=%.0f in_flight=%d error_rate=%.1f%% p99=%dus\n",
+				float64(windowSent)/interval.Seconds(),
+				atomic.LoadInt64(&p.inFlight),
+				errRate,
+				p99,
+			)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// requestTemplate describes one kind of request a scenario can issue. URL,
+// Body, and each header value are text/template strings evaluated once per
+// request, so placeholders like {{randInt 0 1024}}, {{uuid}}, and {{seq}}
+// produce a fresh value on every iteration. Weight controls how often this
+// template is picked relative to the others in the same scenario file; it
+// is ignored when there is only one template.
+type requestTemplate struct {
+	Method  string            `json:"method" yaml:"method"`
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	Body    string            `json:"body" yaml:"body"`
+	Weight  int               `json:"weight" yaml:"weight"`
+}
+
+// headerFlags implements flag.Value to collect repeated -header "K: V"
+// flags into a map.
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	var parts []string
+	for k, v := range h {
+		parts = append(parts, k+": "+v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h headerFlags) Set(value string) error {
+	k, v, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf(`header %q must be in "Key: Value" form`, value)
+	}
+	h[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	return nil
+}
+
+var (
+	methodFlag      = flag.String("method", http.MethodGet, "HTTP method to use when --scenario is not set")
+	bodyFlag        = flag.String("body", "", "request body template to use when --scenario is not set")
+	urlTemplateFlag = flag.String("url-template", "http://localhost:8080/rate/foo/{{randInt 0 1024}}", "URL template to use when --scenario is not set")
+	scenarioFlag    = flag.String("scenario", "", "path to a YAML or JSON file listing weighted request templates; overrides --method/--header/--body/--url-template")
+	headersFlag     = make(headerFlags)
+)
+
+func init() {
+	flag.Var(headersFlag, "header", `extra request header in "Key: Value" form, e.g. -header "Content-Type: application/json"; repeatable`)
+}
+
+var seqCounter int64
+
+// templateFuncs are the placeholders available inside a requestTemplate's
+// URL, body, and header values.
+var templateFuncs = template.FuncMap{
+	"randInt": randInt,
+	"uuid":    func() string { return uuid.NewString() },
+	"seq":     func() int64 { return atomic.AddInt64(&seqCounter, 1) },
+}
+
+// randInt returns a random int in [min, max). max == min pins a constant
+// value rather than panicking, since rand.Intn(0) panics; max < min is a
+// template authoring mistake and is reported as a rendering error instead.
+func randInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randInt: max %d is less than min %d", max, min)
+	}
+	if max == min {
+		return min, nil
+	}
+	return min + rand.Intn(max-min), nil
+}
+
+// loadTemplates builds the list of weighted request templates to draw
+// from, either by parsing --scenario or by wrapping the individual
+// --method/--header/--body/--url-template flags into a single template.
+func loadTemplates() ([]requestTemplate, error) {
+	if *scenarioFlag == "" {
+		return []requestTemplate{{
+			Method:  *methodFlag,
+			URL:     *urlTemplateFlag,
+			Headers: headersFlag,
+			Body:    *bodyFlag,
+			Weight:  1,
+		}}, nil
+	}
+
+	data, err := os.ReadFile(*scenarioFlag)
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario file: %w", err)
+	}
+
+	var templates []requestTemplate
+	switch ext := filepath.Ext(*scenarioFlag); ext {
+	case ".json":
+		err = json.Unmarshal(data, &templates)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &templates)
+	default:
+		return nil, fmt.Errorf("unsupported scenario file extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing scenario file: %w", err)
+	}
+	return templates, nil
+}
+
+// parsedTemplate is a requestTemplate with its text/template fields
+// pre-parsed, so rendering a request on the hot path is just Execute.
+type parsedTemplate struct {
+	method  string
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+	weight  int
+}
+
+// scenario holds the parsed templates a run draws requests from and picks
+// among them by weight.
+type scenario struct {
+	templates   []parsedTemplate
+	totalWeight int
+}
+
+func newScenario(templates []requestTemplate) (*scenario, error) {
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("scenario has no request templates")
+	}
+
+	s := &scenario{}
+	for i, t := range templates {
+		parsed, err := parseTemplate(t)
+		if err != nil {
+			return nil, fmt.Errorf("template %d: %w", i, err)
+		}
+		s.templates = append(s.templates, parsed)
+		s.totalWeight += parsed.weight
+	}
+	return s, nil
+}
+
+func parseTemplate(t requestTemplate) (parsedTemplate, error) {
+	weight := t.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+
+	urlTmpl, err := template.New("url").Funcs(templateFuncs).Parse(t.URL)
+	if err != nil {
+		return parsedTemplate{}, fmt.Errorf("url template: %w", err)
+	}
+
+	var bodyTmpl *template.Template
+	if t.Body != "" {
+		bodyTmpl, err = template.New("body").Funcs(templateFuncs).Parse(t.Body)
+		if err != nil {
+			return parsedTemplate{}, fmt.Errorf("body template: %w", err)
+		}
+	}
+
+	headers := make(map[string]*template.Template, len(t.Headers))
+	for k, v := range t.Headers {
+		tmpl, err := template.New("header").Funcs(templateFuncs).Parse(v)
+		if err != nil {
+			return parsedTemplate{}, fmt.Errorf("header %q template: %w", k, err)
+		}
+		headers[k] = tmpl
+	}
+
+	method := t.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	return parsedTemplate{
+		method:  method,
+		url:     urlTmpl,
+		body:    bodyTmpl,
+		headers: headers,
+		weight:  weight,
+	}, nil
+}
+
+// next renders one *http.Request by picking a template weighted at random
+// and evaluating its URL, body, and header templates.
+func (s *scenario) next() (*http.Request, error) {
+	t := s.pick()
+
+	var url bytes.Buffer
+	if err := t.url.Execute(&url, nil); err != nil {
+		return nil, fmt.Errorf("rendering url: %w", err)
+	}
+
+	var body io.Reader
+	if t.body != nil {
+		var buf bytes.Buffer
+		if err := t.body.Execute(&buf, nil); err != nil {
+			return nil, fmt.Errorf("rendering body: %w", err)
+		}
+		body = &buf
+	}
+
+	req, err := http.NewRequest(t.method, url.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	for k, tmpl := range t.headers {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			return nil, fmt.Errorf("rendering header %q: %w", k, err)
+		}
+		req.Header.Set(k, buf.String())
+	}
+
+	return req, nil
+}
+
+func (s *scenario) pick() parsedTemplate {
+	if len(s.templates) == 1 {
+		return s.templates[0]
+	}
+
+	r := rand.Intn(s.totalWeight)
+	for _, t := range s.templates {
+		if r < t.weight {
+			return t
+		}
+		r -= t.weight
+	}
+	return s.templates[len(s.templates)-1]
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestScenarioNextRendersTemplates(t *testing.T) {
+	s, err := newScenario([]requestTemplate{{
+		Method: "POST",
+		URL:    "http://localhost:8080/rate/foo/{{seq}}",
+		Headers: map[string]string{
+			"X-Test": "{{uuid}}",
+		},
+		Body: `{"n": {{randInt 0 1}}}`,
+	}})
+	if err != nil {
+		t.Fatalf("newScenario: %v", err)
+	}
+
+	req, err := s.next()
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	if req.Method != "POST" {
+		t.Fatalf("Method = %q, want POST", req.Method)
+	}
+	if req.Header.Get("X-Test") == "" {
+		t.Fatalf("expected X-Test header to be rendered, got empty string")
+	}
+	if req.Body == nil {
+		t.Fatalf("expected a non-nil body")
+	}
+}
+
+func TestNewScenarioRejectsNoTemplates(t *testing.T) {
+	if _, err := newScenario(nil); err == nil {
+		t.Fatalf("newScenario(nil): expected error, got nil")
+	}
+	if _, err := newScenario([]requestTemplate{}); err == nil {
+		t.Fatalf("newScenario([]requestTemplate{}): expected error, got nil")
+	}
+}
+
+func TestRandIntEqualBoundsReturnsConstant(t *testing.T) {
+	got, err := randInt(5, 5)
+	if err != nil {
+		t.Fatalf("randInt(5, 5): unexpected error: %v", err)
+	}
+	if got != 5 {
+		t.Fatalf("randInt(5, 5) = %d, want 5", got)
+	}
+}
+
+func TestRandIntMaxLessThanMinErrors(t *testing.T) {
+	if _, err := randInt(5, 1); err == nil {
+		t.Fatalf("randInt(5, 1): expected error, got nil")
+	}
+}
+
+func TestScenarioNextInvalidTemplateErrors(t *testing.T) {
+	_, err := newScenario([]requestTemplate{{
+		URL: "http://localhost:8080/{{",
+	}})
+	if err == nil {
+		t.Fatalf("newScenario with malformed template: expected error, got nil")
+	}
+}
+
+func TestScenarioPickSingleTemplateIgnoresWeight(t *testing.T) {
+	s, err := newScenario([]requestTemplate{{URL: "http://localhost:8080/a", Weight: 0}})
+	if err != nil {
+		t.Fatalf("newScenario: %v", err)
+	}
+	if len(s.templates) != 1 {
+		t.Fatalf("expected exactly one template, got %d", len(s.templates))
+	}
+	if got := s.pick(); got.weight != 1 {
+		t.Fatalf("zero weight should default to 1, got %d", got.weight)
+	}
+}
+
+func TestScenarioPickOnlyReturnsKnownTemplates(t *testing.T) {
+	s, err := newScenario([]requestTemplate{
+		{URL: "http://localhost:8080/a", Weight: 1},
+		{URL: "http://localhost:8080/b", Weight: 9},
+	})
+	if err != nil {
+		t.Fatalf("newScenario: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		req, err := s.next()
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		seen[req.URL.Path] = true
+	}
+	if !seen["/a"] || !seen["/b"] {
+		t.Fatalf("expected both templates to be picked across 100 draws, saw %v", seen)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// workerStats collects latency and outcome data for a single worker
+// goroutine. Each worker owns its histogram exclusively, so recording a
+// sample never contends with any other goroutine; the histograms are only
+// merged once, after all workers have finished.
+type workerStats struct {
+	hist     *hdrhistogram.Histogram
+	statuses map[int]int64
+	failed   int64
+
+	// droppedSamples counts latencies RecordValue rejected as out of the
+	// histogram's [1us, 1min] range (e.g. a request hung long enough to
+	// blow past --duration); the request itself is still counted in
+	// failed/statuses, only its latency sample is missing from the
+	// percentiles.
+	droppedSamples int64
+}
+
+// newWorkerStats returns a histogram tracking latencies from 1 microsecond
+// to 1 minute with 3 significant figures of precision, which is enough
+// resolution to separate p50 from p999 on typical HTTP round trips.
+func newWorkerStats() *workerStats {
+	return &workerStats{
+		hist:     hdrhistogram.New(1, int64(time.Minute/time.Microsecond), 3),
+		statuses: make(map[int]int64),
+	}
+}
+
+// record stores one request's outcome. status is 0 for requests that never
+// got a response (connection errors, timeouts, etc).
+func (w *workerStats) record(elapsed time.Duration, status int) {
+	if err := w.hist.RecordValue(elapsed.Microseconds()); err != nil {
+		w.droppedSamples++
+	}
+	if status == 0 {
+		w.failed++
+		return
+	}
+	w.statuses[status]++
+}
+
+// mergeStats combines per-worker stats into a single histogram and status
+// table and prints the resulting summary.
+func mergeStats(workers []*workerStats) {
+	merged := newWorkerStats()
+	for _, w := range workers {
+		merged.hist.Merge(w.hist)
+		merged.failed += w.failed
+		merged.droppedSamples += w.droppedSamples
+		for code, n := range w.statuses {
+			merged.statuses[code] += n
+		}
+	}
+
+	var success int64
+	for _, n := range merged.statuses {
+		success += n
+	}
+
+	fmt.Printf("latency (us): p50=%d p90=%d p99=%d p999=%d max=%d\n",
+		merged.hist.ValueAtQuantile(50),
+		merged.hist.ValueAtQuantile(90),
+		merged.hist.ValueAtQuantile(99),
+		merged.hist.ValueAtQuantile(99.9),
+		merged.hist.Max(),
+	)
+	fmt.Printf("requests: %d succeeded, %d failed\n", success, merged.failed)
+	for code, n := range merged.statuses {
+		fmt.Printf("  status %d: %d\n", code, n)
+	}
+	if merged.droppedSamples > 0 {
+		fmt.Printf("  %d latency samples fell outside the histogram's trackable range and are excluded from the percentiles above\n", merged.droppedSamples)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"time"
+)
+
+var (
+	maxConnsPerHost     = flag.Int("max-conns-per-host", 0, "http.Transport MaxConnsPerHost; 0 means unlimited. Set this >= --workers to avoid workers queuing behind the transport's own connection cap")
+	maxIdleConnsPerHost = flag.Int("max-idle-conns-per-host", 150, "http.Transport MaxIdleConnsPerHost; should generally match --workers so every worker can keep its connection alive between requests")
+	idleConnTimeout     = flag.Duration("idle-conn-timeout", 90*time.Second, "http.Transport IdleConnTimeout")
+	disableKeepAlives   = flag.Bool("disable-keep-alives", false, "disable HTTP keep-alives, forcing a new connection per request")
+)
+
+// newClient builds the single *http.Client shared by all worker goroutines.
+// A shared client (and therefore a shared transport) is what allows
+// connections to be pooled and reused across requests; --max-conns-per-host
+// caps concurrent connections to the target host regardless of how many
+// workers are running, so raising --workers past that cap just increases
+// queuing inside the transport rather than increasing concurrency on the
+// wire.
+func newClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			MaxConnsPerHost:     *maxConnsPerHost,
+			MaxIdleConnsPerHost: *maxIdleConnsPerHost,
+			IdleConnTimeout:     *idleConnTimeout,
+			DisableKeepAlives:   *disableKeepAlives,
+		},
+	}
+}